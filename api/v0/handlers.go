@@ -0,0 +1,345 @@
+// Package v0 contains the http.Handler constructors for the shortener's
+// routes. Handlers depend only on the storage.Store interface, not a
+// concrete backend, so they can be exercised against an in-memory Store in
+// tests or a --store=memory dev mode that runs without Redis.
+package v0
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jf2978/backend-eng-assignment/metadata"
+	"github.com/jf2978/backend-eng-assignment/serve/middleware"
+	"github.com/jf2978/backend-eng-assignment/storage"
+)
+
+// ShortUrlRequest represents a req
+type ShortUrlRequest struct {
+	Original  string         `json:"url"`
+	Custom    string         `json:"custom_suffix"`
+	ExpiresIn time.Duration  `json:"expires_in,omitempty"`
+	MaxVisits int64          `json:"max_visits,omitempty"`
+	Rules     []storage.Rule `json:"rules,omitempty"`
+}
+
+// resolveRecord looks a record up first by suffix, then (if not found) by
+// treating suffix as a custom suffix and resolving its hash.
+func resolveRecord(ctx context.Context, st storage.Store, suffix string) (*storage.ShortUrl, error) {
+	rec, err := st.Get(ctx, suffix)
+	if err == nil {
+		return rec, nil
+	}
+	if err != storage.ErrNotFound {
+		return nil, err
+	}
+
+	recordId, err := st.ResolveHash(ctx, storage.HashOf(suffix))
+	if err != nil {
+		return nil, err
+	}
+
+	return st.Get(ctx, recordId)
+}
+
+// GreeterHandler returns a closure responsible for
+// greeting the caller with an optional name parameter
+func GreeterHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		// optional name param
+		name := "world"
+		if val, ok := vars["name"]; ok {
+			log.Printf("name: %v\n", val)
+			name = val
+		}
+
+		var resp map[string]interface{}
+		json.Unmarshal([]byte(fmt.Sprintf(`{ "message": "hello %s\n!" }`, name)), &resp)
+
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
+
+// ShortUrlResponse is the wire shape returned by ShortUrlHandler: the same
+// fields as storage.ShortUrl, but with default_url/custom_url built as
+// fully-qualified links via the reverse router rather than bare suffixes.
+// The revocation token is derived on demand from the keyring rather than
+// stored on the record, so it's never written to the store.
+type ShortUrlResponse struct {
+	Original        string           `json:"original_url"`
+	Default         string           `json:"default_url"`
+	Custom          string           `json:"custom_url,omitempty"`
+	Metadata        storage.Metadata `json:"metadata"`
+	RevocationToken string           `json:"revocation_token"`
+}
+
+// ShortUrlHandler returns a closure responsible for
+// fetching or generating a shortened url for the provided original
+func ShortUrlHandler(st storage.Store, links *LinkBuilder, keyring *Keyring) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		ctx := r.Context()
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var shortReq ShortUrlRequest
+		if err := json.Unmarshal(body, &shortReq); err != nil {
+			http.Error(w, "provided payload is not valid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if shortReq.Original == "" {
+			http.Error(w, "required param 'url' is empty", http.StatusBadRequest)
+			return
+		}
+
+		// claiming a custom suffix requires proof the caller holds the
+		// signing secret, so a guessed/squatted suffix can't be claimed out
+		// from under its rightful owner
+		if shortReq.Custom != "" && !keyring.Verify(body, r.Header.Get("X-Signature")) {
+			http.Error(w, "invalid or missing X-Signature", http.StatusUnauthorized)
+			return
+		}
+
+		var shortUrl storage.ShortUrl
+		shortUrl.Original = shortReq.Original
+
+		ogHash := storage.HashOf(shortReq.Original)
+		ogRecordId, err := st.ResolveHash(ctx, ogHash)
+		if err != nil && err != storage.ErrNotFound {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// if we do have an associated record for this url, get it
+		if ogRecordId != "" {
+			existing, getErr := st.Get(ctx, ogRecordId)
+			if getErr != nil && getErr != storage.ErrNotFound {
+				http.Error(w, getErr.Error(), http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				shortUrl = *existing
+			}
+		}
+
+		// otherwise, generate a new url
+		if err == storage.ErrNotFound {
+			suffix, suffixErr := storage.ReserveUniqueSuffix(ctx, st)
+			if suffixErr != nil {
+				if suffixErr == storage.ErrSuffixAttemptsExhausted {
+					http.Error(w, suffixErr.Error(), http.StatusConflict)
+					return
+				}
+				http.Error(w, suffixErr.Error(), http.StatusInternalServerError)
+				return
+			}
+			shortUrl.Default = suffix
+
+			encodedHist, histErr := metadata.New(now)
+			if histErr != nil {
+				http.Error(w, histErr.Error(), http.StatusInternalServerError)
+				return
+			}
+			shortUrl.Metadata.CreatedAt = now
+			shortUrl.Metadata.EncodedHist = encodedHist
+		}
+
+		// only overwrite an existing record's caps/rules when the request
+		// actually supplies them, so re-POSTing an already-shortened url
+		// just to fetch its default_url doesn't silently wipe them
+		if shortReq.MaxVisits > 0 {
+			shortUrl.Metadata.MaxVisits = shortReq.MaxVisits
+		}
+		if len(shortReq.Rules) > 0 {
+			shortUrl.Metadata.Rules = shortReq.Rules
+		}
+
+		if shortReq.ExpiresIn > 0 {
+			expiresAt := now.Add(shortReq.ExpiresIn)
+			shortUrl.Metadata.ExpiresAt = &expiresAt
+		}
+
+		customHash := storage.HashOf(shortReq.Custom)
+
+		// side effect: this will NOT overwrite an existing custom suffix for the current record
+		// i.e. a single original url can be mapped to multiple custom suffixes
+		if shortReq.Custom != "" {
+			// check if the custom suffix is already in use (both as someone
+			// else's custom url or the unlikely case that this was a generated suffix)
+			customRecordId, resolveErr := st.ResolveHash(ctx, customHash)
+			if resolveErr != nil && resolveErr != storage.ErrNotFound {
+				http.Error(w, resolveErr.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			if customRecordId != "" && customRecordId != ogRecordId {
+				http.Error(w, "custom url provided is already in use", http.StatusBadRequest)
+				return
+			}
+
+			// if we either have a matching record or none at all, let's write/update our custom url data
+			shortUrl.Custom = shortReq.Custom
+		}
+
+		if err := st.PutRecord(ctx, &shortUrl, shortUrl.RemainingTTL(now), ogHash, customHash); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		defaultURL, err := links.Build(r, shortUrl.Default)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		customURL, err := links.Build(r, shortUrl.Custom)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ShortUrlResponse{
+			Original:        shortUrl.Original,
+			Default:         defaultURL,
+			Custom:          customURL,
+			Metadata:        shortUrl.Metadata,
+			RevocationToken: keyring.RevocationToken(shortUrl.Default),
+		})
+	})
+}
+
+// RedirectHandler returns a closure responsible for
+// redirecting a default or custom url to its original. While readOnly is
+// enabled, the redirect itself still succeeds but the visit metadata write
+// is skipped, so existing links keep resolving during a maintenance window
+// or data migration.
+func RedirectHandler(st storage.Store, readOnly *middleware.ReadOnlyGuard) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		suffix := vars["suffix"]
+
+		if suffix == "" {
+			http.Error(w, "redirect url is empty", http.StatusBadRequest)
+			return
+		}
+
+		shortUrl, err := resolveRecord(ctx, st, suffix)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("could not redirect url: %s\n", suffix), http.StatusNotFound)
+			return
+		}
+
+		if shortUrl.IsExpired(now) {
+			http.Error(w, fmt.Sprintf("short url has expired: %s\n", suffix), http.StatusGone)
+			return
+		}
+
+		if readOnly.Enabled() {
+			http.Redirect(w, r, shortUrl.ResolveRedirectTarget(r, now), http.StatusFound)
+			return
+		}
+
+		// UpdateVisit re-reads and retries under the hood, so two
+		// concurrent redirects against the same record can't lose one
+		// another's visit increment.
+		updated, err := st.UpdateVisit(ctx, shortUrl.Default, shortUrl.RemainingTTL(now), func(rec *storage.ShortUrl) error {
+			encodedHist, err := metadata.Record(rec.Metadata.EncodedHist, rec.Metadata.CreatedAt, time.Now())
+			if err != nil {
+				return err
+			}
+			rec.Metadata.EncodedHist = encodedHist
+			rec.Metadata.Visits++
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, updated.ResolveRedirectTarget(r, now), http.StatusFound)
+	})
+}
+
+// DeleteHandler returns a closure responsible for
+// revoking a default or custom url so it can no longer be resolved
+func DeleteHandler(st storage.Store, keyring *Keyring) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		suffix := vars["suffix"]
+
+		if suffix == "" {
+			http.Error(w, "url is empty", http.StatusBadRequest)
+			return
+		}
+
+		shortUrl, err := resolveRecord(ctx, st, suffix)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("could not find url: %s\n", suffix), http.StatusNotFound)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || !keyring.VerifyRevocationToken(shortUrl.Default, token) {
+			http.Error(w, "invalid or missing revocation token", http.StatusUnauthorized)
+			return
+		}
+
+		if err := st.DeleteRecord(ctx, shortUrl); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// InfoHandler returns a closure responsible for
+// returning metadata for the provided url
+func InfoHandler(st storage.Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		vars := mux.Vars(r)
+		suffix := vars["suffix"]
+
+		if suffix == "" {
+			http.Error(w, "url is empty", http.StatusBadRequest)
+			return
+		}
+
+		shortUrl, err := resolveRecord(ctx, st, suffix)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("could not find url: %s\n", suffix), http.StatusNotFound)
+			return
+		}
+
+		stats, err := metadata.BuildStats(shortUrl.Metadata.EncodedHist, shortUrl.Metadata.CreatedAt, shortUrl.Metadata.Visits, r.URL.Query().Get("bucket"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if err := writeStats(w, r, stats); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	})
+}
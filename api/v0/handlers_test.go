@@ -0,0 +1,175 @@
+package v0
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+	"github.com/jf2978/backend-eng-assignment/storage"
+)
+
+func postShorten(handler http.Handler, url string) (ShortUrlResponse, *httptest.ResponseRecorder) {
+	return postShortenRequest(handler, ShortUrlRequest{Original: url})
+}
+
+func postShortenRequest(handler http.Handler, req ShortUrlRequest) (ShortUrlResponse, *httptest.ResponseRecorder) {
+	body, _ := json.Marshal(req)
+	r := httptest.NewRequest(http.MethodPost, "/shorten/", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	var resp ShortUrlResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp, w
+}
+
+func newTestHandler(st storage.Store) http.Handler {
+	router := mux.NewRouter()
+	router.Handle("/{suffix}/", RedirectHandler(st, nil)).Methods(http.MethodGet).Name("redirect")
+	links := &LinkBuilder{Router: router, BaseURL: func(r *http.Request) string { return "https://short.url" }}
+	return ShortUrlHandler(st, links, &Keyring{})
+}
+
+// TestShortUrlHandler_RePostPreservesCapsRulesAndTTL re-POSTs an
+// already-shortened original url without resupplying max_visits/rules/
+// expires_in, and asserts the existing record's max_visits, rules and
+// expires_at survive in the response instead of being silently wiped.
+func TestShortUrlHandler_RePostPreservesCapsRulesAndTTL(t *testing.T) {
+	st := storage.NewMemStore()
+	handler := newTestHandler(st)
+
+	first, w := postShortenRequest(handler, ShortUrlRequest{
+		Original:  "https://example.com/preserve-me",
+		MaxVisits: 5,
+		Rules:     []storage.Rule{{Target: "https://example.com/mobile", UserAgentContains: "Mobile"}},
+		ExpiresIn: time.Hour,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("initial POST: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	second, w := postShortenRequest(handler, ShortUrlRequest{Original: "https://example.com/preserve-me"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("re-POST: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if second.Default != first.Default {
+		t.Fatalf("expected the same record's default_url, got %q then %q", first.Default, second.Default)
+	}
+	if second.Metadata.MaxVisits != 5 {
+		t.Errorf("expected max_visits to survive a re-POST, got %d", second.Metadata.MaxVisits)
+	}
+	if len(second.Metadata.Rules) != 1 {
+		t.Errorf("expected rules to survive a re-POST, got %+v", second.Metadata.Rules)
+	}
+	if second.Metadata.ExpiresAt == nil {
+		t.Errorf("expected expires_at to survive a re-POST, got nil")
+	}
+}
+
+// faultInjectingHook fails every Nth pipeline processed against the client,
+// simulating PutRecord's transactional write crashing partway through under
+// real concurrent load.
+type faultInjectingHook struct {
+	every int32
+	count int32
+}
+
+func (h *faultInjectingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+func (h *faultInjectingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	return nil
+}
+
+func (h *faultInjectingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	if atomic.AddInt32(&h.count, 1)%h.every == 0 {
+		return ctx, fmt.Errorf("injected fault")
+	}
+	return ctx, nil
+}
+
+func (h *faultInjectingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	return nil
+}
+
+// TestShortUrlHandler_ConcurrentRequestsNoDuplicateSuffixesOrDanglingHashes
+// fires N concurrent shorten requests against a real (miniredis-backed)
+// Store with a fault-injecting hook failing a fraction of the underlying
+// transactional writes, and asserts two invariants hold despite the
+// injected failures: no suffix is ever handed out twice, and no hash
+// association is left dangling (pointing at a record that was never
+// actually committed).
+func TestShortUrlHandler_ConcurrentRequestsNoDuplicateSuffixesOrDanglingHashes(t *testing.T) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rdb.Close()
+	rdb.AddHook(&faultInjectingHook{every: 7})
+
+	st := storage.NewRedisStore(rdb)
+	handler := newTestHandler(st)
+
+	const n = 25
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/%d", i)
+	}
+
+	var wg sync.WaitGroup
+	suffixes := make(chan string, n)
+
+	for _, url := range urls {
+		wg.Add(1)
+		go func(url string) {
+			defer wg.Done()
+			resp, w := postShorten(handler, url)
+			if w.Code == http.StatusOK {
+				suffixes <- resp.Default
+			}
+		}(url)
+	}
+
+	wg.Wait()
+	close(suffixes)
+
+	seen := make(map[string]bool)
+	for s := range suffixes {
+		if seen[s] {
+			t.Fatalf("suffix %q was handed out more than once", s)
+		}
+		seen[s] = true
+	}
+
+	ctx := context.Background()
+	for _, url := range urls {
+		suffix, err := rdb.Get(ctx, storage.HashOf(url)).Result()
+		if err == redis.Nil {
+			continue // this url's write never landed at all, which is fine
+		}
+		if err != nil {
+			t.Fatalf("unexpected error resolving hash for %q: %v", url, err)
+		}
+
+		if _, err := rdb.Get(ctx, suffix).Result(); err == redis.Nil {
+			t.Fatalf("dangling hash association: %q resolves to suffix %q, but no record exists for it", url, suffix)
+		} else if err != nil {
+			t.Fatalf("unexpected error fetching record for suffix %q: %v", suffix, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package v0
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// BaseURLFunc resolves the externally visible base url (scheme + host) to
+// use when building a fully-qualified link for a given request.
+type BaseURLFunc func(r *http.Request) string
+
+// LinkBuilder builds fully-qualified links to the named "redirect" route
+// via reverse routing, so a client gets a real clickable url (and a future
+// path change, e.g. /{suffix}/ -> /r/{suffix}, doesn't break anyone who'd
+// otherwise be string-concatenating the suffix themselves).
+type LinkBuilder struct {
+	Router  *mux.Router
+	BaseURL BaseURLFunc
+}
+
+// Build returns the fully-qualified redirect link for suffix, or "" if
+// suffix is empty (e.g. no custom suffix was set on this record).
+func (b *LinkBuilder) Build(r *http.Request, suffix string) (string, error) {
+	if suffix == "" {
+		return "", nil
+	}
+
+	route := b.Router.Get("redirect")
+	if route == nil {
+		return "", fmt.Errorf(`no "redirect" route registered`)
+	}
+
+	u, err := route.URL("suffix", suffix)
+	if err != nil {
+		return "", err
+	}
+
+	return b.BaseURL(r) + u.Path, nil
+}
@@ -0,0 +1,56 @@
+package v0
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestLinkBuilder_Build(t *testing.T) {
+	t.Run("empty suffix", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.Handle("/{suffix}/", RedirectHandler(nil, nil)).Name("redirect")
+		b := &LinkBuilder{Router: router, BaseURL: func(r *http.Request) string { return "https://short.url" }}
+
+		got, err := b.Build(httptest.NewRequest(http.MethodGet, "/", nil), "")
+		if err != nil {
+			t.Fatalf("Build: %v", err)
+		}
+		if got != "" {
+			t.Errorf("expected empty link for an empty suffix, got %q", got)
+		}
+	})
+
+	t.Run("missing redirect route", func(t *testing.T) {
+		b := &LinkBuilder{Router: mux.NewRouter(), BaseURL: func(r *http.Request) string { return "https://short.url" }}
+
+		_, err := b.Build(httptest.NewRequest(http.MethodGet, "/", nil), "abc123")
+		if err == nil {
+			t.Fatalf("expected an error when no \"redirect\" route is registered")
+		}
+	})
+
+	t.Run("builds a fully-qualified link from BaseURL", func(t *testing.T) {
+		router := mux.NewRouter()
+		router.Handle("/{suffix}/", RedirectHandler(nil, nil)).Name("redirect")
+		b := &LinkBuilder{
+			Router: router,
+			BaseURL: func(r *http.Request) string {
+				return "https://" + r.Header.Get("X-Forwarded-Host")
+			},
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Forwarded-Host", "short.url")
+
+		got, err := b.Build(r, "abc123")
+		if err != nil {
+			t.Fatalf("Build: %v", err)
+		}
+		if want := "https://short.url/abc123/"; got != want {
+			t.Errorf("expected %q, got %q", want, got)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package v0
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// SUSSecretEnv is the environment variable holding the current signing key.
+const SUSSecretEnv = "SUS_SECRET"
+
+// Keyring holds the current HMAC signing key plus the previous one, so a key
+// can be rotated without invalidating signatures/tokens issued just before
+// the rotation. The previous key is only ever used for verification.
+type Keyring struct {
+	mu       sync.RWMutex
+	current  []byte
+	previous []byte
+}
+
+// NewKeyringFromEnv loads the current signing key from SUS_SECRET. It panics
+// if the secret is unset, since an unsigned shortener is not something this
+// server should silently fall back to.
+func NewKeyringFromEnv() *Keyring {
+	secret := os.Getenv(SUSSecretEnv)
+	if secret == "" {
+		panic(fmt.Sprintf("%s must be set", SUSSecretEnv))
+	}
+
+	return &Keyring{current: []byte(secret)}
+}
+
+// Rotate makes newKey the current signing key, keeping the previous current
+// key around for verification only.
+func (k *Keyring) Rotate(newKey []byte) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.previous = k.current
+	k.current = newKey
+}
+
+// sign computes the hex-encoded HMAC-SHA256 of data under the given key.
+func sign(key, data []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sign computes the hex-encoded HMAC-SHA256 of data under the current key.
+func (k *Keyring) Sign(data []byte) string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return sign(k.current, data)
+}
+
+// Verify reports whether sig is a valid HMAC-SHA256 of data under the
+// current or previous key, comparing in constant time.
+func (k *Keyring) Verify(data []byte, sig string) bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	want := sign(k.current, data)
+	if subtle.ConstantTimeCompare([]byte(sig), []byte(want)) == 1 {
+		return true
+	}
+
+	if k.previous == nil {
+		return false
+	}
+
+	wantPrevious := sign(k.previous, data)
+	return subtle.ConstantTimeCompare([]byte(sig), []byte(wantPrevious)) == 1
+}
+
+// RevocationToken derives a bearer token for the given record id, used to
+// authorize a later DELETE /{suffix}/.
+func (k *Keyring) RevocationToken(recordID string) string {
+	return k.Sign([]byte("revoke:" + recordID))
+}
+
+// VerifyRevocationToken reports whether token authorizes revoking recordID.
+func (k *Keyring) VerifyRevocationToken(recordID, token string) bool {
+	return k.Verify([]byte("revoke:"+recordID), token)
+}
+
+// RotateKeysHandler returns a closure responsible for rotating the signing
+// keyring to the new key supplied in the JSON body. Callers must already
+// hold a key the keyring verifies with (the current or the previous one),
+// checked via an X-Signature computed over the new_key value.
+func RotateKeysHandler(keyring *Keyring) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			NewKey string `json:"new_key"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "provided payload is not valid JSON", http.StatusBadRequest)
+			return
+		}
+
+		if body.NewKey == "" {
+			http.Error(w, "required param 'new_key' is empty", http.StatusBadRequest)
+			return
+		}
+
+		if !keyring.Verify([]byte(body.NewKey), r.Header.Get("X-Signature")) {
+			http.Error(w, "invalid or missing X-Signature", http.StatusUnauthorized)
+			return
+		}
+
+		keyring.Rotate([]byte(body.NewKey))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
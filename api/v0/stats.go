@@ -0,0 +1,35 @@
+package v0
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jf2978/backend-eng-assignment/metadata"
+)
+
+// writeStats encodes stats as JSON or CSV depending on the request's
+// Accept header, defaulting to JSON.
+func writeStats(w http.ResponseWriter, r *http.Request, stats metadata.Stats) error {
+	if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		w.Header().Set("Content-Type", "text/csv")
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"from", "to", "count"}); err != nil {
+			return err
+		}
+		for _, b := range stats.Buckets {
+			row := []string{b.From.Format(time.RFC3339), b.To.Format(time.RFC3339), strconv.FormatInt(b.Count, 10)}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(stats)
+}
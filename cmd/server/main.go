@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	v0 "github.com/jf2978/backend-eng-assignment/api/v0"
+	"github.com/jf2978/backend-eng-assignment/serve"
+	"github.com/jf2978/backend-eng-assignment/serve/middleware"
+	"github.com/jf2978/backend-eng-assignment/storage"
+)
+
+const (
+	DefaultAddress = "localhost"
+	ServerPort     = "8080"
+	DBPort         = "6379"
+)
+
+var (
+	listenAddr     = flag.String("listen", fmt.Sprintf("%s:%s", DefaultAddress, ServerPort), "address to listen on")
+	lameDuckPeriod = flag.Duration("lame-duck", 5*time.Second, "how long to wait for in-flight requests to finish during a graceful shutdown")
+	readOnly       = flag.Bool("read-only", os.Getenv("READ_ONLY") == "1", "reject mutating requests (e.g. POST /shorten/) while true; also settable via READ_ONLY=1")
+	publicURL      = flag.String("public-url", "", `base url (e.g. "https://short.url") used to build fully-qualified links; derived from proxy headers per-request if unset`)
+	storeBackend   = flag.String("store", "redis", `storage backend: "redis", or "memory" for local dev without Redis`)
+)
+
+// InitServer returns a new serve.Server wired against the Store selected by
+// --store: Redis by default, or an in-memory Store for --store=memory.
+func InitServer() *serve.Server {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	keyring := v0.NewKeyringFromEnv()
+
+	switch *storeBackend {
+	case "memory":
+		return serve.New(storage.NewMemStore(), nil, logger, middleware.NewReadOnlyGuard(*readOnly), *publicURL, keyring)
+	case "redis":
+	default:
+		log.Fatalf(`unknown --store %q (want "redis" or "memory")`, *storeBackend)
+	}
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%s", DefaultAddress, DBPort),
+		Password: "", // no password set
+		DB:       0,  // use default DB
+	})
+
+	return serve.New(storage.NewRedisStore(rdb), rdb, logger, middleware.NewReadOnlyGuard(*readOnly), *publicURL, keyring)
+}
+
+func main() {
+	flag.Parse()
+
+	if err := InitServer().Run(*listenAddr, *lameDuckPeriod); err != nil {
+		log.Fatal(err)
+	}
+}
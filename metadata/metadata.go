@@ -0,0 +1,52 @@
+// Package metadata owns the HdrHistogram encoding/decoding used to track a
+// record's visits.
+package metadata
+
+import (
+	"time"
+
+	hdr "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// window is how far out a freshly initialized histogram is sized to record
+// visits before it needs to be re-initialized.
+const window = time.Hour * 24 * 30
+
+// New encodes a freshly initialized histogram spanning window from now,
+// ready to record visit latencies (seconds elapsed since now).
+func New(now time.Time) ([]byte, error) {
+	end := now.Add(window)
+
+	hist := hdr.New(1, int64(window.Seconds()), 5)
+	hist.SetStartTimeMs(now.UnixMilli())
+	hist.SetEndTimeMs(end.UnixMilli())
+
+	return hist.Encode(hdr.V2CompressedEncodingCookieBase)
+}
+
+// Record decodes encoded, records a visit at t, and returns the re-encoded
+// histogram. The recorded value is the number of seconds elapsed between
+// createdAt and t, not t's raw absolute timestamp: HdrHistogram only
+// guarantees significant-figure precision relative to a value's magnitude,
+// so recording absolute epoch seconds (~10 digits) left too little
+// precision to distinguish visits that were only hours apart. Elapsed
+// seconds since creation stay small for the life of the window, so the
+// same significant figures resolve to the second for roughly the first
+// three days (262144s) and to 16s or better for the rest of the window.
+func Record(encoded []byte, createdAt time.Time, t time.Time) ([]byte, error) {
+	hist, err := hdr.Decode(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	elapsed := int64(t.Sub(createdAt).Seconds())
+	if elapsed < 1 {
+		elapsed = 1
+	}
+
+	if err := hist.RecordValue(elapsed); err != nil {
+		return nil, err
+	}
+
+	return hist.Encode(hdr.V2CompressedEncodingCookieBase)
+}
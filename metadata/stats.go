@@ -0,0 +1,85 @@
+package metadata
+
+import (
+	"sort"
+	"time"
+
+	hdr "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// BucketCount is the visit count recorded in a single time bucket.
+type BucketCount struct {
+	From  time.Time `json:"from"`
+	To    time.Time `json:"to"`
+	Count int64     `json:"count"`
+}
+
+// Stats is the decoded, client-facing view of a record's visit histogram:
+// never the raw EncodedHist blob.
+type Stats struct {
+	Visits      int64            `json:"visits"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Buckets     []BucketCount    `json:"buckets"`
+	Percentiles map[string]int64 `json:"percentiles"`
+}
+
+// bucketDuration maps a ?bucket= query value to its truncation window.
+// Anything unrecognized (including the empty string) defaults to "day".
+func bucketDuration(bucket string) time.Duration {
+	switch bucket {
+	case "hour":
+		return time.Hour
+	case "week":
+		return 7 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// BuildStats decodes encoded into a Stats: visit counts grouped into
+// buckets of the requested granularity, plus p50/p90/p99 latencies between
+// createdAt and each recorded visit. encoded's values are seconds elapsed
+// since createdAt (see Record), not absolute timestamps.
+func BuildStats(encoded []byte, createdAt time.Time, visits int64, bucket string) (Stats, error) {
+	hist, err := hdr.Decode(encoded)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	window := bucketDuration(bucket)
+	counts := make(map[int64]int64)
+
+	for _, bar := range hist.Distribution() {
+		if bar.Count == 0 {
+			continue
+		}
+
+		mid := createdAt.Add(time.Duration((bar.From+bar.To)/2) * time.Second)
+		key := mid.Truncate(window).Unix()
+		counts[key] += bar.Count
+	}
+
+	buckets := make([]BucketCount, 0, len(counts))
+	for from, count := range counts {
+		buckets = append(buckets, BucketCount{
+			From:  time.Unix(from, 0),
+			To:    time.Unix(from, 0).Add(window),
+			Count: count,
+		})
+	}
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].From.Before(buckets[j].From) })
+
+	percentiles := map[string]int64{
+		"p50": hist.ValueAtQuantile(50),
+		"p90": hist.ValueAtQuantile(90),
+		"p99": hist.ValueAtQuantile(99),
+	}
+
+	return Stats{
+		Visits:      visits,
+		CreatedAt:   createdAt,
+		Buckets:     buckets,
+		Percentiles: percentiles,
+	}, nil
+}
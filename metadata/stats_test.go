@@ -0,0 +1,42 @@
+package metadata
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildStats_BucketsAndPercentiles(t *testing.T) {
+	createdAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	encoded, err := New(createdAt)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	visits := []time.Duration{time.Hour, 2 * time.Hour, 25 * time.Hour}
+	for _, d := range visits {
+		encoded, err = Record(encoded, createdAt, createdAt.Add(d))
+		if err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	stats, err := BuildStats(encoded, createdAt, int64(len(visits)), "day")
+	if err != nil {
+		t.Fatalf("BuildStats: %v", err)
+	}
+
+	if stats.Visits != int64(len(visits)) {
+		t.Errorf("expected %d visits, got %d", len(visits), stats.Visits)
+	}
+
+	if len(stats.Buckets) != 2 {
+		t.Errorf("expected visits split across 2 day buckets, got %d: %+v", len(stats.Buckets), stats.Buckets)
+	}
+
+	for name, v := range stats.Percentiles {
+		if v < 0 {
+			t.Errorf("percentile %s should never be negative, got %d", name, v)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package serve
+
+import (
+	"net/http"
+	"strings"
+
+	v0 "github.com/jf2978/backend-eng-assignment/api/v0"
+)
+
+// newBaseURLFunc returns a v0.BaseURLFunc pinned to publicURL if set,
+// otherwise one that derives the scheme+host per-request from the
+// Forwarded or X-Forwarded-Host headers a reverse proxy sets, falling back
+// to the request's own Host.
+func newBaseURLFunc(publicURL string) v0.BaseURLFunc {
+	publicURL = strings.TrimSuffix(publicURL, "/")
+
+	return func(r *http.Request) string {
+		if publicURL != "" {
+			return publicURL
+		}
+
+		if fwd := r.Header.Get("Forwarded"); fwd != "" {
+			if host := forwardedHost(fwd); host != "" {
+				return "https://" + host
+			}
+		}
+
+		if host := r.Header.Get("X-Forwarded-Host"); host != "" {
+			return "https://" + host
+		}
+
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+
+		return scheme + "://" + r.Host
+	}
+}
+
+// forwardedHost extracts the host= parameter from the first entry of a
+// Forwarded header (RFC 7239), e.g. `Forwarded: for=1.2.3.4;host=short.url`.
+func forwardedHost(fwd string) string {
+	first := strings.Split(fwd, ",")[0]
+	for _, part := range strings.Split(first, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.EqualFold(k, "host") {
+			return strings.Trim(v, `"`)
+		}
+	}
+
+	return ""
+}
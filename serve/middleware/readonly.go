@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// ReadOnlyGuard gates mutating requests behind a shared, toggleable flag,
+// reusable across every write route so future write endpoints inherit the
+// same guard without each wiring its own check.
+type ReadOnlyGuard struct {
+	enabled int32
+}
+
+// NewReadOnlyGuard returns a ReadOnlyGuard initialized to enabled.
+func NewReadOnlyGuard(enabled bool) *ReadOnlyGuard {
+	g := &ReadOnlyGuard{}
+	g.Set(enabled)
+	return g
+}
+
+// Set toggles the guard on or off.
+func (g *ReadOnlyGuard) Set(enabled bool) {
+	v := int32(0)
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&g.enabled, v)
+}
+
+// Enabled reports whether the guard is currently rejecting writes.
+func (g *ReadOnlyGuard) Enabled() bool {
+	return atomic.LoadInt32(&g.enabled) == 1
+}
+
+// RejectWrites returns middleware that responds 503 to any request reaching
+// it while the guard is enabled. Wrap only the routes that mutate state
+// (e.g. POST /shorten/) so reads keep working during a maintenance window
+// or data migration.
+func (g *ReadOnlyGuard) RejectWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.Enabled() {
+			http.Error(w, "service is in read-only mode", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
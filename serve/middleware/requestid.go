@@ -0,0 +1,52 @@
+// Package middleware provides the http.Handler wrappers the serve package
+// chains onto every route: request id propagation, structured access
+// logging, and response compression.
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+type contextKey string
+
+// RequestIDHeader is the header a caller can supply a trace id on, and the
+// header the response echoes it back on.
+const RequestIDHeader = "X-Request-Id"
+
+// requestIDContextKey is the context.Context key RequestID stores the
+// request id under.
+const requestIDContextKey contextKey = "requestID"
+
+// RequestID reads X-Request-Id off the incoming request, generating one if
+// absent, stashes it in the request context, and echoes it back on the
+// response so a client and server can correlate the same request.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = generateRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), requestIDContextKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext returns the request id stashed by RequestID, or ""
+// if none is present.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDContextKey).(string)
+	return id
+}
+
+func generateRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
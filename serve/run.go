@@ -0,0 +1,48 @@
+package serve
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Run listens on addr until a SIGINT/SIGTERM is received, then stops
+// accepting new connections and waits up to lameDuck for in-flight handlers
+// to finish before closing the underlying Store connection (if any).
+func (s *Server) Run(addr string, lameDuck time.Duration) error {
+	httpServer := &http.Server{Addr: addr, Handler: s.Router}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info("listening", "addr", addr)
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case sig := <-sigCh:
+		s.logger.Info("shutting down", "signal", sig.String(), "lame_duck", lameDuck.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lameDuck)
+	defer cancel()
+
+	shutdownErr := httpServer.Shutdown(ctx)
+
+	if s.dataStore != nil {
+		if err := s.dataStore.Close(); err != nil && shutdownErr == nil {
+			return err
+		}
+	}
+
+	return shutdownErr
+}
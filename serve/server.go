@@ -0,0 +1,63 @@
+// Package serve wires the api/v0 routes onto a chosen storage.Store
+// implementation behind a single Server handle.
+package serve
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+	v0 "github.com/jf2978/backend-eng-assignment/api/v0"
+	"github.com/jf2978/backend-eng-assignment/serve/middleware"
+	"github.com/jf2978/backend-eng-assignment/storage"
+)
+
+// Server holds the router wired onto a Store, plus whatever backend
+// connection (if any) that Store needs closed on shutdown.
+type Server struct {
+	context   context.Context
+	Router    *mux.Router
+	dataStore *redis.Client
+	logger    *slog.Logger
+
+	// BaseURL resolves the externally visible scheme+host for a request,
+	// used to build the fully-qualified links ShortUrlHandler returns.
+	BaseURL v0.BaseURLFunc
+}
+
+// New returns a Server with routes wired onto st, with request id
+// propagation, access logging, and response compression applied to every
+// route. dataStore is retained only for lifecycle management (e.g. closing
+// the connection on shutdown) and may be nil when st isn't Redis-backed.
+// readOnly gates every mutating route; pass middleware.NewReadOnlyGuard(false)
+// to leave writes enabled. publicURL pins BaseURL to a fixed value (e.g.
+// "https://short.url"); pass "" to derive it per-request from proxy headers.
+// keyring authorizes custom suffixes, revocation, and its own rotation.
+func New(st storage.Store, dataStore *redis.Client, logger *slog.Logger, readOnly *middleware.ReadOnlyGuard, publicURL string, keyring *v0.Keyring) *Server {
+	r := mux.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(middleware.AccessLog(logger))
+	r.Use(middleware.Compress)
+
+	baseURL := newBaseURLFunc(publicURL)
+
+	r.Handle("/hello", v0.GreeterHandler())
+	r.Handle("/hello/{name}/", v0.GreeterHandler())
+	r.Handle("/{suffix}/", v0.RedirectHandler(st, readOnly)).Methods(http.MethodGet).Name("redirect")
+	r.Handle("/{suffix}/", readOnly.RejectWrites(v0.DeleteHandler(st, keyring))).Methods(http.MethodDelete)
+	r.Handle("/{suffix}/stats/", v0.InfoHandler(st)) // todo: handle non-trailing slash
+	r.Handle("/admin/keys/rotate", v0.RotateKeysHandler(keyring)).Methods(http.MethodPost)
+
+	links := &v0.LinkBuilder{Router: r, BaseURL: baseURL}
+	r.Handle("/shorten/", readOnly.RejectWrites(v0.ShortUrlHandler(st, links, keyring)))
+
+	return &Server{
+		context:   context.Background(),
+		Router:    r,
+		dataStore: dataStore,
+		logger:    logger,
+		BaseURL:   baseURL,
+	}
+}
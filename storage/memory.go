@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memEntry is a hash -> suffix association, with an optional expiry applied
+// lazily on read (there's no background sweeper).
+type memEntry struct {
+	suffix    string
+	expiresAt time.Time // zero means no expiry
+}
+
+// memStore is an in-memory Store, for local dev without Redis (--store=memory)
+// and for unit tests that need a fast, disposable backend. Its single mutex
+// makes every method trivially atomic, including UpdateVisit.
+type memStore struct {
+	mu      sync.Mutex
+	records map[string]ShortUrl
+	hashes  map[string]memEntry
+}
+
+// NewMemStore returns a Store backed by process memory. Nothing is
+// persisted across restarts.
+func NewMemStore() Store {
+	return &memStore{
+		records: make(map[string]ShortUrl),
+		hashes:  make(map[string]memEntry),
+	}
+}
+
+// resolveHash looks up hash, lazily evicting it (and reporting a miss) if
+// its expiry has passed.
+func (s *memStore) resolveHash(hash string, now time.Time) (string, bool) {
+	entry, ok := s.hashes[hash]
+	if !ok {
+		return "", false
+	}
+
+	if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+		delete(s.hashes, hash)
+		return "", false
+	}
+
+	return entry.suffix, true
+}
+
+func (s *memStore) Get(ctx context.Context, suffix string) (*ShortUrl, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[suffix]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	cp := rec
+	return &cp, nil
+}
+
+func (s *memStore) ResolveHash(ctx context.Context, hash string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	suffix, ok := s.resolveHash(hash, time.Now())
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return suffix, nil
+}
+
+func (s *memStore) PutRecord(ctx context.Context, rec *ShortUrl, ttl time.Duration, hashes ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	s.records[rec.Default] = *rec
+	for _, hash := range hashes {
+		s.hashes[hash] = memEntry{suffix: rec.Default, expiresAt: expiresAt}
+	}
+
+	return nil
+}
+
+func (s *memStore) ReserveSuffix(ctx context.Context, suffix string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[suffix]; ok {
+		return false, nil
+	}
+
+	s.records[suffix] = ShortUrl{Default: suffix}
+	return true, nil
+}
+
+func (s *memStore) DeleteRecord(ctx context.Context, rec *ShortUrl) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, rec.Default)
+	delete(s.hashes, HashOf(rec.Original))
+	if rec.Custom != "" {
+		delete(s.hashes, HashOf(rec.Custom))
+	}
+
+	return nil
+}
+
+func (s *memStore) UpdateVisit(ctx context.Context, suffix string, ttl time.Duration, update func(*ShortUrl) error) (*ShortUrl, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[suffix]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if err := update(&rec); err != nil {
+		return nil, err
+	}
+	s.records[suffix] = rec
+
+	cp := rec
+	return &cp, nil
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// maxUpdateAttempts bounds how many times UpdateVisit retries after losing
+// a race to a concurrent writer of the same record, before giving up. It's
+// sized generously above the realistic worst case (every other concurrent
+// writer landing their commit first) so a genuinely hot record doesn't
+// spuriously exhaust retries under normal traffic.
+const maxUpdateAttempts = 50
+
+// updateRetryBackoff is the jittered delay between retries, which spreads
+// out retrying writers so they don't all re-collide on the next attempt.
+const updateRetryBackoff = 5 * time.Millisecond
+
+// errUpdateAttemptsExhausted is returned when a record is contended enough
+// that UpdateVisit can't land a write within maxUpdateAttempts.
+var errUpdateAttemptsExhausted = fmt.Errorf("storage: too many concurrent writers racing this record, giving up")
+
+// redisStore is the production Store backend, persisting records and hash
+// associations as plain Redis keys.
+type redisStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisStore returns a Store backed by rdb.
+func NewRedisStore(rdb *redis.Client) Store {
+	return &redisStore{rdb: rdb}
+}
+
+func (s *redisStore) Get(ctx context.Context, suffix string) (*ShortUrl, error) {
+	serialized, err := s.rdb.Get(ctx, suffix).Result()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rec ShortUrl
+	if err := json.Unmarshal([]byte(serialized), &rec); err != nil {
+		return nil, err
+	}
+
+	return &rec, nil
+}
+
+func (s *redisStore) ResolveHash(ctx context.Context, hash string) (string, error) {
+	suffix, err := s.rdb.Get(ctx, hash).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return suffix, nil
+}
+
+func (s *redisStore) PutRecord(ctx context.Context, rec *ShortUrl, ttl time.Duration, hashes ...string) error {
+	serialized, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, rec.Default, string(serialized), ttl)
+		for _, hash := range hashes {
+			pipe.Set(ctx, hash, rec.Default, ttl)
+		}
+		return nil
+	})
+
+	return err
+}
+
+func (s *redisStore) ReserveSuffix(ctx context.Context, suffix string) (bool, error) {
+	return s.rdb.SetNX(ctx, suffix, "reserved", 0).Result()
+}
+
+func (s *redisStore) DeleteRecord(ctx context.Context, rec *ShortUrl) error {
+	hashes := []string{rec.Default, HashOf(rec.Original)}
+	if rec.Custom != "" {
+		hashes = append(hashes, HashOf(rec.Custom))
+	}
+	return s.rdb.Del(ctx, hashes...).Err()
+}
+
+func (s *redisStore) UpdateVisit(ctx context.Context, suffix string, ttl time.Duration, update func(*ShortUrl) error) (*ShortUrl, error) {
+	var rec ShortUrl
+
+	for attempt := 0; attempt < maxUpdateAttempts; attempt++ {
+		txErr := s.rdb.Watch(ctx, func(tx *redis.Tx) error {
+			serialized, err := tx.Get(ctx, suffix).Result()
+			if err == redis.Nil {
+				return ErrNotFound
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := json.Unmarshal([]byte(serialized), &rec); err != nil {
+				return err
+			}
+
+			if err := update(&rec); err != nil {
+				return err
+			}
+
+			updated, err := json.Marshal(&rec)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, suffix, string(updated), ttl)
+				return nil
+			})
+
+			return err
+		}, suffix)
+
+		if txErr == nil {
+			return &rec, nil
+		}
+		if txErr == redis.TxFailedErr {
+			// a concurrent writer committed first; back off with jitter so
+			// we don't immediately re-collide with the same writers, then
+			// retry against the new value
+			time.Sleep(time.Duration(rand.Int63n(int64(updateRetryBackoff))))
+			continue
+		}
+
+		return nil, txErr
+	}
+
+	return nil, errUpdateAttemptsExhausted
+}
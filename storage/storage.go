@@ -0,0 +1,162 @@
+// Package storage defines the Store interface that abstracts how a ShortUrl
+// record is persisted and looked up, so the api/v0 handlers can run against
+// Redis in production, an in-memory Store in tests, or any other backend
+// that implements this interface (e.g. Postgres, DynamoDB).
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ShortUrl is a single shortened-url record: the original destination, the
+// generated (and optional custom) suffix it resolves from, and visit metadata.
+type ShortUrl struct {
+	Original string   `json:"original_url"`
+	Default  string   `json:"default_url"`
+	Custom   string   `json:"custom_url"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// Metadata tracks a record's visit history as an encoded HdrHistogram, so
+// percentile/bucketed stats can be derived without storing every visit
+// timestamp individually, plus the record's optional expiry/visit-cap and
+// rule-based redirect configuration.
+type Metadata struct {
+	CreatedAt   time.Time  `json:"created_at"`
+	Visits      int64      `json:"visits"`
+	EncodedHist []byte     `json:"encoded_hist"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	MaxVisits   int64      `json:"max_visits,omitempty"`
+	Rules       []Rule     `json:"rules,omitempty"`
+}
+
+// Rule describes a conditional redirect target that RedirectHandler
+// evaluates, in order, against attributes of the incoming request. The
+// first rule whose conditions all match wins; any condition left at its
+// zero value is treated as "don't care".
+type Rule struct {
+	Target            string `json:"target"`
+	UserAgentContains string `json:"user_agent_contains,omitempty"`
+	AcceptLanguage    string `json:"accept_language,omitempty"`
+	Country           string `json:"country,omitempty"`
+	// StartHour/EndHour bound a time-of-day window in UTC, e.g. 9-17.
+	// Both zero means no time-of-day restriction.
+	StartHour int `json:"start_hour,omitempty"`
+	EndHour   int `json:"end_hour,omitempty"`
+}
+
+// Matches reports whether the rule's conditions are satisfied by the given request.
+func (rule Rule) Matches(r *http.Request, now time.Time) bool {
+	if rule.UserAgentContains != "" && !strings.Contains(r.UserAgent(), rule.UserAgentContains) {
+		return false
+	}
+
+	if rule.AcceptLanguage != "" && !strings.Contains(r.Header.Get("Accept-Language"), rule.AcceptLanguage) {
+		return false
+	}
+
+	if rule.Country != "" && r.Header.Get("X-Country") != rule.Country {
+		return false
+	}
+
+	if rule.StartHour != 0 || rule.EndHour != 0 {
+		hour := now.UTC().Hour()
+		if rule.StartHour <= rule.EndHour {
+			if hour < rule.StartHour || hour >= rule.EndHour {
+				return false
+			}
+		} else if hour < rule.StartHour && hour >= rule.EndHour {
+			// wraps past midnight, e.g. 22-6
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsExpired reports whether the record has passed its TTL or visit cap.
+func (s *ShortUrl) IsExpired(now time.Time) bool {
+	if s.Metadata.ExpiresAt != nil && now.After(*s.Metadata.ExpiresAt) {
+		return true
+	}
+
+	if s.Metadata.MaxVisits > 0 && s.Metadata.Visits >= s.Metadata.MaxVisits {
+		return true
+	}
+
+	return false
+}
+
+// ResolveRedirectTarget evaluates the record's rules, in order, against the
+// incoming request and returns the first matching rule's target, falling
+// back to the original url if no rule matches.
+func (s *ShortUrl) ResolveRedirectTarget(r *http.Request, now time.Time) string {
+	for _, rule := range s.Metadata.Rules {
+		if rule.Matches(r, now) {
+			return rule.Target
+		}
+	}
+
+	return s.Original
+}
+
+// RemainingTTL returns the duration until expiry, or 0 (no expiry) if unset.
+func (s *ShortUrl) RemainingTTL(now time.Time) time.Duration {
+	if s.Metadata.ExpiresAt == nil {
+		return 0
+	}
+
+	return s.Metadata.ExpiresAt.Sub(now)
+}
+
+// ErrNotFound is returned by Store methods when the requested key has no
+// associated record, in place of a backend-specific "not found" error.
+var ErrNotFound = fmt.Errorf("storage: record not found")
+
+// Store abstracts the persistence layer a ShortUrl is read from and written
+// to. Implementations must return ErrNotFound (not a backend-specific
+// error) when a key doesn't exist, so callers can branch on it regardless
+// of backend.
+type Store interface {
+	// Get returns the record stored under suffix, or ErrNotFound.
+	Get(ctx context.Context, suffix string) (*ShortUrl, error)
+
+	// ResolveHash returns the suffix a given hash (of an original or custom
+	// url) resolves to, or ErrNotFound.
+	ResolveHash(ctx context.Context, hash string) (string, error)
+
+	// PutRecord atomically writes rec under its Default suffix together
+	// with a hash -> suffix association for each of hashes, so a crash or
+	// timeout partway through never leaves an orphaned record or a
+	// dangling hash association. ttl is the time-to-live applied to the
+	// record and its hash associations; the zero value means no expiry.
+	PutRecord(ctx context.Context, rec *ShortUrl, ttl time.Duration, hashes ...string) error
+
+	// UpdateVisit atomically reads the record stored under suffix, applies
+	// update to it, and writes the result back, retrying on a conflict
+	// with a concurrent writer of the same record. This prevents the
+	// classic lost-update race where two concurrent redirects both read,
+	// both increment Visits, and one increment is silently dropped. ttl is
+	// re-applied to the record on write, since a plain rewrite would
+	// otherwise reset a Redis-backed record's remaining expiry to none.
+	UpdateVisit(ctx context.Context, suffix string, ttl time.Duration, update func(*ShortUrl) error) (*ShortUrl, error)
+
+	// ReserveSuffix atomically claims suffix for a new record, returning
+	// false (no error) if it's already taken.
+	ReserveSuffix(ctx context.Context, suffix string) (bool, error)
+
+	// DeleteRecord removes rec's Default suffix and its original/custom
+	// hash associations.
+	DeleteRecord(ctx context.Context, rec *ShortUrl) error
+}
+
+// HashOf returns the hex-encoded SHA-256 of s, the key the Store uses to
+// look up a record by its original or custom url.
+func HashOf(s string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(s)))
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// backends returns every Store implementation under test. Every backend
+// added here must pass the conformance suite below.
+func backends(t *testing.T) map[string]Store {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+
+	return map[string]Store{
+		"memory": NewMemStore(),
+		"redis":  NewRedisStore(rdb),
+	}
+}
+
+// TestStore_Conformance exercises the Store contract identically against
+// every backend, so a new implementation can't drift from the behavior
+// handlers actually depend on (namely: ErrNotFound on misses, and
+// PutRecord committing a record and its hash associations together).
+func TestStore_Conformance(t *testing.T) {
+	for name, st := range backends(t) {
+		st := st
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := st.Get(ctx, "missing"); err != ErrNotFound {
+				t.Fatalf("Get on missing suffix: expected ErrNotFound, got %v", err)
+			}
+
+			if _, err := st.ResolveHash(ctx, "unassociated"); err != ErrNotFound {
+				t.Fatalf("ResolveHash on unassociated hash: expected ErrNotFound, got %v", err)
+			}
+
+			rec := &ShortUrl{
+				Original: "https://example.com",
+				Default:  "abc123",
+				Metadata: Metadata{CreatedAt: time.Now()},
+			}
+			ogHash := HashOf(rec.Original)
+			if err := st.PutRecord(ctx, rec, 0, ogHash); err != nil {
+				t.Fatalf("PutRecord: %v", err)
+			}
+
+			got, err := st.Get(ctx, rec.Default)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got.Original != rec.Original {
+				t.Errorf("expected Original %q, got %q", rec.Original, got.Original)
+			}
+
+			suffix, err := st.ResolveHash(ctx, ogHash)
+			if err != nil {
+				t.Fatalf("ResolveHash: %v", err)
+			}
+			if suffix != rec.Default {
+				t.Errorf("expected suffix %q, got %q", rec.Default, suffix)
+			}
+
+			updated, err := st.UpdateVisit(ctx, rec.Default, 0, func(r *ShortUrl) error {
+				r.Metadata.Visits++
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("UpdateVisit: %v", err)
+			}
+			if updated.Metadata.Visits != 1 {
+				t.Errorf("expected 1 visit after UpdateVisit, got %d", updated.Metadata.Visits)
+			}
+
+			if _, err := st.UpdateVisit(ctx, "missing", 0, func(r *ShortUrl) error { return nil }); err != ErrNotFound {
+				t.Fatalf("UpdateVisit on missing suffix: expected ErrNotFound, got %v", err)
+			}
+
+			ok, err := st.ReserveSuffix(ctx, "fresh-suffix")
+			if err != nil {
+				t.Fatalf("ReserveSuffix: %v", err)
+			}
+			if !ok {
+				t.Errorf("expected ReserveSuffix to claim an unused suffix")
+			}
+			if ok, err := st.ReserveSuffix(ctx, "fresh-suffix"); err != nil || ok {
+				t.Errorf("expected ReserveSuffix to report false on an already-claimed suffix, got (%v, %v)", ok, err)
+			}
+
+			if err := st.DeleteRecord(ctx, rec); err != nil {
+				t.Fatalf("DeleteRecord: %v", err)
+			}
+			if _, err := st.Get(ctx, rec.Default); err != ErrNotFound {
+				t.Errorf("Get after DeleteRecord: expected ErrNotFound, got %v", err)
+			}
+			if _, err := st.ResolveHash(ctx, ogHash); err != ErrNotFound {
+				t.Errorf("ResolveHash after DeleteRecord: expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+// TestStore_UpdateVisitNoLostUpdates races concurrent UpdateVisit calls
+// against the same record and asserts every increment lands: the scenario
+// that motivated UpdateVisit's WATCH + optimistic retry in the first place.
+func TestStore_UpdateVisitNoLostUpdates(t *testing.T) {
+	for name, st := range backends(t) {
+		st := st
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			rec := &ShortUrl{Original: "https://example.com/race", Default: "racey"}
+			if err := st.PutRecord(ctx, rec, 0); err != nil {
+				t.Fatalf("PutRecord: %v", err)
+			}
+
+			const concurrency = 20
+			var wg sync.WaitGroup
+			wg.Add(concurrency)
+			for i := 0; i < concurrency; i++ {
+				go func() {
+					defer wg.Done()
+					if _, err := st.UpdateVisit(ctx, rec.Default, 0, func(r *ShortUrl) error {
+						r.Metadata.Visits++
+						return nil
+					}); err != nil {
+						t.Errorf("UpdateVisit: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			final, err := st.Get(ctx, rec.Default)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if final.Metadata.Visits != concurrency {
+				t.Errorf("expected %d visits with no lost updates, got %d", concurrency, final.Metadata.Visits)
+			}
+		})
+	}
+}
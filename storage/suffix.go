@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// DefaultNumRandomBytes is the size of the random suffix generated for each
+// new record, before url-safe base64 encoding.
+const DefaultNumRandomBytes = 8
+
+// maxSuffixAttempts bounds how many times ReserveUniqueSuffix retries before
+// giving up and surfacing a conflict to the caller.
+const maxSuffixAttempts = 10
+
+// ErrSuffixAttemptsExhausted is returned once ReserveUniqueSuffix has
+// retried maxSuffixAttempts times without winning a reservation.
+var ErrSuffixAttemptsExhausted = fmt.Errorf("could not reserve a unique suffix after %d attempts", maxSuffixAttempts)
+
+// ReserveUniqueSuffix generates random suffixes and atomically reserves the
+// first one not already claimed (via st.ReserveSuffix), so two concurrent
+// requests can never walk away believing they each own the same suffix.
+func ReserveUniqueSuffix(ctx context.Context, st Store) (string, error) {
+	for attempt := 0; attempt < maxSuffixAttempts; attempt++ {
+		b, err := generateRandomBytes(DefaultNumRandomBytes)
+		if err != nil {
+			return "", err
+		}
+		suffix := generateRandomUrlSafeString(b)
+
+		ok, err := st.ReserveSuffix(ctx, suffix)
+		if err != nil {
+			return "", err
+		}
+
+		if ok {
+			return suffix, nil
+		}
+	}
+
+	return "", ErrSuffixAttemptsExhausted
+}
+
+// generateRandomUrlSafeString will return the provided byte slice as a url-safe base64-encoded string
+func generateRandomUrlSafeString(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// generateRandomBytes will produce n RNG bytes
+func generateRandomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
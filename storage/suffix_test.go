@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+// alwaysTakenStore reports every suffix as already reserved, to exercise
+// ReserveUniqueSuffix's exhausted-retries path without needing real
+// collisions.
+type alwaysTakenStore struct {
+	Store
+}
+
+func (alwaysTakenStore) ReserveSuffix(ctx context.Context, suffix string) (bool, error) {
+	return false, nil
+}
+
+func TestReserveUniqueSuffix_ExhaustsAttempts(t *testing.T) {
+	_, err := ReserveUniqueSuffix(context.Background(), alwaysTakenStore{})
+	if err != ErrSuffixAttemptsExhausted {
+		t.Fatalf("expected ErrSuffixAttemptsExhausted, got %v", err)
+	}
+}
+
+func TestReserveUniqueSuffix_ClaimsAtomically(t *testing.T) {
+	st := NewMemStore()
+	ctx := context.Background()
+
+	suffix, err := ReserveUniqueSuffix(ctx, st)
+	if err != nil {
+		t.Fatalf("ReserveUniqueSuffix: %v", err)
+	}
+
+	// a second reservation of the same suffix must be rejected, proving the
+	// claim really landed (and isn't just a Get-then-assume-unused check).
+	ok, err := st.ReserveSuffix(ctx, suffix)
+	if err != nil {
+		t.Fatalf("ReserveSuffix: %v", err)
+	}
+	if ok {
+		t.Errorf("expected the suffix ReserveUniqueSuffix returned to already be claimed")
+	}
+}